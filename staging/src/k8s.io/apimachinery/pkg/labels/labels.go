@@ -17,10 +17,11 @@ limitations under the License.
 package labels
 
 import (
-	"fmt"
+	"errors"
 	"sort"
 	"strings"
 
+	"k8s.io/apimachinery/pkg/selection"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
@@ -154,6 +155,116 @@ func Equals(labels1, labels2 Set) bool {
 	return true
 }
 
+// Diff compares old and new and returns the labels added by new, the
+// labels present in old but missing from new, and the labels present in
+// both whose value changed (reported with new's value). Diff never
+// mutates old or new.
+func Diff(old, new Set) (added, removed, changed Set) {
+	added = Set{}
+	removed = Set{}
+	changed = Set{}
+
+	for k, v := range new {
+		if oldValue, ok := old[k]; !ok {
+			added[k] = v
+		} else if oldValue != v {
+			changed[k] = v
+		}
+	}
+	for k, v := range old {
+		if !new.Has(k) {
+			removed[k] = v
+		}
+	}
+	return added, removed, changed
+}
+
+// Apply returns a new Set with patch merged into base (patch wins on
+// conflicting keys) and the keys in remove deleted. base and patch are
+// left untouched.
+func Apply(base Set, patch Set, remove []string) Set {
+	result := make(Set, len(base)+len(patch))
+	for k, v := range base {
+		result[k] = v
+	}
+	for k, v := range patch {
+		result[k] = v
+	}
+	for _, k := range remove {
+		delete(result, k)
+	}
+	return result
+}
+
+// IsSubset returns true if every key in sub exists in super with an equal
+// value. An empty sub is always a subset.
+func IsSubset(sub, super Set) bool {
+	if len(sub) > len(super) {
+		return false
+	}
+	for k, v := range sub {
+		value, ok := super[k]
+		if !ok || value != v {
+			return false
+		}
+	}
+	return true
+}
+
+// ContainsAll returns true if super has all of the given keys, regardless
+// of their values.
+func ContainsAll(super Set, keys ...string) bool {
+	for _, k := range keys {
+		if !super.Has(k) {
+			return false
+		}
+	}
+	return true
+}
+
+// ErrConvertSelectorToLabelsMapUnsupported is returned by
+// ConvertSelectorToLabelsMap when the selector parses successfully but
+// contains a requirement that cannot be represented as a label map (i.e.
+// anything other than a single-valued equality), letting callers tell an
+// invalid selector apart from one that is merely unsupported in map form.
+var ErrConvertSelectorToLabelsMapUnsupported = errors.New("unable to convert selector to labels map: selector contains non-equality requirements")
+
+// ConvertSelectorToRequirements parses selector with the same grammar as
+// Parse, then splits the result: equality requirements (key=value,
+// key==value) are collected into a Set for callers that only need a map
+// (e.g. podnodeselector-style whitelist checks), while the remaining
+// requirements (!=, in, notin, exists, gt, lt, ...) are returned as
+// []Requirement for callers able to evaluate them directly.
+func ConvertSelectorToRequirements(selector string, opts ...field.PathOption) ([]Requirement, Set, error) {
+	labelsMap := Set{}
+
+	if len(selector) == 0 {
+		return nil, labelsMap, nil
+	}
+
+	parsedSelector, err := Parse(selector, opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	requirements, _ := parsedSelector.Requirements()
+
+	var rest []Requirement
+	for _, r := range requirements {
+		if r.Operator() != selection.Equals && r.Operator() != selection.DoubleEquals {
+			rest = append(rest, r)
+			continue
+		}
+		values := r.Values().List()
+		if len(values) != 1 {
+			rest = append(rest, r)
+			continue
+		}
+		labelsMap[r.Key()] = values[0]
+	}
+	return rest, labelsMap, nil
+}
+
 // ConvertSelectorToLabelsMap converts selector string to labels map
 // and validates keys and values
 func ConvertSelectorToLabelsMap(selector string, opts ...field.PathOption) (Set, error) {
@@ -163,21 +274,13 @@ func ConvertSelectorToLabelsMap(selector string, opts ...field.PathOption) (Set,
 		return labelsMap, nil
 	}
 
-	labels := strings.Split(selector, ",")
-	for _, label := range labels {
-		l := strings.Split(label, "=")
-		if len(l) != 2 {
-			return labelsMap, fmt.Errorf("invalid selector: %s", l)
-		}
-		key := strings.TrimSpace(l[0])
-		if err := validateLabelKey(key, field.ToPath(opts...)); err != nil {
-			return labelsMap, err
-		}
-		value := strings.TrimSpace(l[1])
-		if err := validateLabelValue(key, value, field.ToPath(opts...)); err != nil {
-			return labelsMap, err
-		}
-		labelsMap[key] = value
+	rest, parsedMap, err := ConvertSelectorToRequirements(selector, opts...)
+	if err != nil {
+		return labelsMap, err
+	}
+	if len(rest) > 0 {
+		return labelsMap, ErrConvertSelectorToLabelsMapUnsupported
 	}
+	labelsMap = parsedMap
 	return labelsMap, nil
 }
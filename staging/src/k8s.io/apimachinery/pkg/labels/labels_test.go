@@ -0,0 +1,258 @@
+/*
+Copyright 2014 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package labels
+
+import (
+	"fmt"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+func TestIsSubset(t *testing.T) {
+	cases := []struct {
+		name  string
+		sub   Set
+		super Set
+		want  bool
+	}{
+		{
+			name:  "empty sub is always a subset",
+			sub:   Set{},
+			super: Set{"a": "1"},
+			want:  true,
+		},
+		{
+			name:  "equal keys and values",
+			sub:   Set{"a": "1", "b": "2"},
+			super: Set{"a": "1", "b": "2"},
+			want:  true,
+		},
+		{
+			name:  "sub is a proper subset",
+			sub:   Set{"a": "1"},
+			super: Set{"a": "1", "b": "2"},
+			want:  true,
+		},
+		{
+			name:  "value mismatch",
+			sub:   Set{"a": "1"},
+			super: Set{"a": "2"},
+			want:  false,
+		},
+		{
+			name:  "key missing from super",
+			sub:   Set{"a": "1", "c": "3"},
+			super: Set{"a": "1", "b": "2"},
+			want:  false,
+		},
+		{
+			name:  "sub larger than super short-circuits",
+			sub:   Set{"a": "1", "b": "2"},
+			super: Set{"a": "1"},
+			want:  false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := IsSubset(c.sub, c.super); got != c.want {
+				t.Errorf("IsSubset(%v, %v) = %v, want %v", c.sub, c.super, got, c.want)
+			}
+		})
+	}
+}
+
+func TestContainsAll(t *testing.T) {
+	cases := []struct {
+		name  string
+		super Set
+		keys  []string
+		want  bool
+	}{
+		{
+			name:  "no keys requested is always true",
+			super: Set{"a": "1"},
+			keys:  nil,
+			want:  true,
+		},
+		{
+			name:  "all keys present",
+			super: Set{"a": "1", "b": "2"},
+			keys:  []string{"a", "b"},
+			want:  true,
+		},
+		{
+			name:  "one key missing",
+			super: Set{"a": "1"},
+			keys:  []string{"a", "b"},
+			want:  false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := ContainsAll(c.super, c.keys...); got != c.want {
+				t.Errorf("ContainsAll(%v, %v) = %v, want %v", c.super, c.keys, got, c.want)
+			}
+		})
+	}
+}
+
+func TestConvertSelectorToLabelsMap(t *testing.T) {
+	successCases := []struct {
+		selector string
+		labels   Set
+	}{
+		{
+			selector: "key=value",
+			labels:   Set{"key": "value"},
+		},
+		{
+			selector: "key1=value1,key2=value2",
+			labels:   Set{"key1": "value1", "key2": "value2"},
+		},
+		{
+			selector: "key==value",
+			labels:   Set{"key": "value"},
+		},
+	}
+	for _, c := range successCases {
+		got, err := ConvertSelectorToLabelsMap(c.selector)
+		if err != nil {
+			t.Errorf("ConvertSelectorToLabelsMap(%q) returned unexpected error: %v", c.selector, err)
+			continue
+		}
+		if !Equals(got, c.labels) {
+			t.Errorf("ConvertSelectorToLabelsMap(%q) = %v, want %v", c.selector, got, c.labels)
+		}
+	}
+
+	unsupportedCases := []string{
+		"key!=value",
+		"key in (a, b)",
+		"key",
+	}
+	for _, selector := range unsupportedCases {
+		_, err := ConvertSelectorToLabelsMap(selector)
+		if err != ErrConvertSelectorToLabelsMapUnsupported {
+			t.Errorf("ConvertSelectorToLabelsMap(%q) returned error %v, want ErrConvertSelectorToLabelsMapUnsupported", selector, err)
+		}
+	}
+
+	_, err := ConvertSelectorToLabelsMap("key=")
+	if err == nil || err == ErrConvertSelectorToLabelsMapUnsupported {
+		t.Errorf("ConvertSelectorToLabelsMap(%q) should have returned a parse error, got %v", "key=", err)
+	}
+}
+
+func TestConvertSelectorToRequirements(t *testing.T) {
+	rest, labelsMap, err := ConvertSelectorToRequirements("key1=value1,key2=value2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("expected no residual requirements for an equality-only selector, got %v", rest)
+	}
+	if !Equals(labelsMap, Set{"key1": "value1", "key2": "value2"}) {
+		t.Errorf("unexpected labels map: %v", labelsMap)
+	}
+
+	rest, labelsMap, err = ConvertSelectorToRequirements("key1=value1,key2!=value2")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !Equals(labelsMap, Set{"key1": "value1"}) {
+		t.Errorf("unexpected labels map: %v", labelsMap)
+	}
+	if len(rest) != 1 || rest[0].Key() != "key2" || rest[0].Operator() != selection.NotEquals {
+		t.Errorf("expected a single residual key2!=value2 requirement, got %v", rest)
+	}
+
+	if _, _, err := ConvertSelectorToRequirements("key="); err == nil {
+		t.Errorf("expected a parse error for a malformed selector")
+	}
+}
+
+func TestDiff(t *testing.T) {
+	old := Set{"a": "1", "b": "2", "c": "3"}
+	new := Set{"a": "1", "b": "20", "d": "4"}
+
+	added, removed, changed := Diff(old, new)
+	if !Equals(added, Set{"d": "4"}) {
+		t.Errorf("unexpected added: %v", added)
+	}
+	if !Equals(removed, Set{"c": "3"}) {
+		t.Errorf("unexpected removed: %v", removed)
+	}
+	if !Equals(changed, Set{"b": "20"}) {
+		t.Errorf("unexpected changed: %v", changed)
+	}
+	if !Equals(old, Set{"a": "1", "b": "2", "c": "3"}) {
+		t.Errorf("Diff mutated old: %v", old)
+	}
+	if !Equals(new, Set{"a": "1", "b": "20", "d": "4"}) {
+		t.Errorf("Diff mutated new: %v", new)
+	}
+}
+
+func TestApply(t *testing.T) {
+	base := Set{"a": "1", "b": "2", "c": "3"}
+	patch := Set{"b": "20", "d": "4"}
+
+	result := Apply(base, patch, []string{"c"})
+	expected := Set{"a": "1", "b": "20", "d": "4"}
+	if !Equals(result, expected) {
+		t.Errorf("got %v, want %v", result, expected)
+	}
+	if !Equals(base, Set{"a": "1", "b": "2", "c": "3"}) {
+		t.Errorf("Apply mutated base: %v", base)
+	}
+	if !Equals(patch, Set{"b": "20", "d": "4"}) {
+		t.Errorf("Apply mutated patch: %v", patch)
+	}
+}
+
+func benchmarkSets() (Set, Set) {
+	old := make(Set, 64)
+	for i := 0; i < 64; i++ {
+		old[fmt.Sprintf("label-%d", i)] = fmt.Sprintf("value-%d", i)
+	}
+	new := make(Set, len(old))
+	for k, v := range old {
+		new[k] = v
+	}
+	new["label-0"] = "changed"
+	return old, new
+}
+
+func BenchmarkDiff(b *testing.B) {
+	old, new := benchmarkSets()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Diff(old, new)
+	}
+}
+
+func BenchmarkApply(b *testing.B) {
+	base, new := benchmarkSets()
+	patch := Set{"label-0": new["label-0"]}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Apply(base, patch, nil)
+	}
+}